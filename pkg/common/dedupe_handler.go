@@ -0,0 +1,94 @@
+package common
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dedupeState is shared across the handlers produced by WithAttrs/WithGroup
+// so dedup tracking stays global to the logger chain rather than resetting
+// every time a child logger is derived.
+type dedupeState struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// DedupingHandler wraps a slog.Handler and suppresses records that are
+// identical (same level, message, and attributes) to one already emitted
+// within window. This keeps something like repeated 401s from scanning the
+// same repo from flooding output.
+type DedupingHandler struct {
+	next   slog.Handler
+	window time.Duration
+	state  *dedupeState
+	// bound holds the attrs and groups added via WithAttrs/WithGroup, written
+	// the same way dedupeKey writes a record's attrs, so they count toward a
+	// record's identity.
+	bound string
+}
+
+// NewDedupingHandler wraps next so that duplicate records within window are
+// dropped.
+func NewDedupingHandler(next slog.Handler, window time.Duration) *DedupingHandler {
+	return &DedupingHandler{
+		next:   next,
+		window: window,
+		state:  &dedupeState{seen: make(map[string]time.Time)},
+	}
+}
+
+func (h *DedupingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *DedupingHandler) Handle(ctx context.Context, r slog.Record) error {
+	key := dedupeKey(h.bound, r)
+	now := time.Now()
+
+	h.state.mu.Lock()
+	last, seen := h.state.seen[key]
+	if seen && now.Sub(last) < h.window {
+		h.state.mu.Unlock()
+		return nil
+	}
+	h.state.seen[key] = now
+	h.state.mu.Unlock()
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *DedupingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.bound)
+	for _, a := range attrs {
+		writeDedupeAttr(&b, a)
+	}
+	return &DedupingHandler{next: h.next.WithAttrs(attrs), window: h.window, state: h.state, bound: b.String()}
+}
+
+func (h *DedupingHandler) WithGroup(name string) slog.Handler {
+	return &DedupingHandler{next: h.next.WithGroup(name), window: h.window, state: h.state, bound: h.bound + "|" + name + ":"}
+}
+
+func dedupeKey(bound string, r slog.Record) string {
+	var b strings.Builder
+	b.WriteString(r.Level.String())
+	b.WriteByte('|')
+	b.WriteString(r.Message)
+	b.WriteString(bound)
+	r.Attrs(func(a slog.Attr) bool {
+		writeDedupeAttr(&b, a)
+		return true
+	})
+	return b.String()
+}
+
+func writeDedupeAttr(b *strings.Builder, a slog.Attr) {
+	b.WriteByte('|')
+	b.WriteString(a.Key)
+	b.WriteByte('=')
+	b.WriteString(a.Value.String())
+}
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestDedupingHandler(buf *bytes.Buffer, window time.Duration) *DedupingHandler {
+	return NewDedupingHandler(slog.NewTextHandler(buf, nil), window)
+}
+
+func TestDedupingHandler_SuppressesWithinWindow(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newTestDedupingHandler(&buf, time.Minute))
+
+	logger.Error("401 from gitlab.example.com", "endpoint", "gitlab.example.com")
+	logger.Error("401 from gitlab.example.com", "endpoint", "gitlab.example.com")
+	logger.Error("401 from gitlab.example.com", "endpoint", "gitlab.example.com")
+
+	got := strings.Count(buf.String(), "401 from gitlab.example.com")
+	if got != 1 {
+		t.Fatalf("got %d occurrences of the duplicate record, want 1 (later ones within the window should be suppressed)", got)
+	}
+}
+
+func TestDedupingHandler_AllowsAfterWindowElapses(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newTestDedupingHandler(&buf, 10*time.Millisecond))
+
+	logger.Error("401 from gitlab.example.com", "endpoint", "gitlab.example.com")
+	time.Sleep(20 * time.Millisecond)
+	logger.Error("401 from gitlab.example.com", "endpoint", "gitlab.example.com")
+
+	got := strings.Count(buf.String(), "401 from gitlab.example.com")
+	if got != 2 {
+		t.Fatalf("got %d occurrences, want 2: a record repeated after the window elapses must not be suppressed", got)
+	}
+}
+
+func TestDedupingHandler_DistinctRecordsAreNotSuppressed(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newTestDedupingHandler(&buf, time.Minute))
+
+	logger.Error("401 from gitlab.example.com", "endpoint", "gitlab.example.com")
+	logger.Error("401 from gitlab.example.com", "endpoint", "other.example.com")
+	logger.Warn("401 from gitlab.example.com", "endpoint", "gitlab.example.com")
+
+	if got := strings.Count(buf.String(), "401 from gitlab.example.com"); got != 3 {
+		t.Fatalf("got %d occurrences, want 3: different attrs/levels must not be deduped against each other", got)
+	}
+}
+
+func TestDedupingHandler_WithAttrsSharesDedupeState(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(newTestDedupingHandler(&buf, time.Minute))
+	child := base.With("endpoint", "gitlab.example.com")
+
+	child.Error("401")
+	base.Error("401", "endpoint", "gitlab.example.com")
+
+	if got := strings.Count(buf.String(), "level=ERROR msg=401"); got != 1 {
+		t.Fatalf("got %d occurrences, want 1: a handler derived via WithAttrs must share dedupe state with its parent", got)
+	}
+}
+
+func TestDedupingHandler_Enabled_DelegatesToNext(t *testing.T) {
+	next := slog.NewTextHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelWarn})
+	h := NewDedupingHandler(next, time.Minute)
+
+	if h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("Enabled(Debug) = true, want false: should delegate to the wrapped handler's level filter")
+	}
+	if !h.Enabled(context.Background(), slog.LevelError) {
+		t.Fatal("Enabled(Error) = false, want true")
+	}
+}
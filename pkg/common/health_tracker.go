@@ -0,0 +1,249 @@
+package common
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ErrCircuitOpen is returned by VerificationHealthTracker.Allow when an
+// endpoint has been tripping errors often enough that live verification
+// against it has been temporarily suspended.
+var ErrCircuitOpen = errors.New("verification circuit open: endpoint is unhealthy")
+
+// Outcome classifies a single verification HTTP call for health tracking
+// purposes.
+type Outcome int
+
+const (
+	OutcomeOK Outcome = iota
+	Outcome5xx
+	OutcomeTimeout
+)
+
+// HealthTrackerConfig tunes when VerificationHealthTracker trips a circuit.
+type HealthTrackerConfig struct {
+	// WindowSize is how far back outcomes are considered when computing the
+	// error rate.
+	WindowSize time.Duration
+	// MinSamples is the minimum number of outcomes within WindowSize before
+	// the error rate is trusted enough to open a circuit.
+	MinSamples int
+	// ErrorRateThreshold is the fraction (0-1) of non-OK outcomes within the
+	// window that trips the circuit.
+	ErrorRateThreshold float64
+	// CooldownPeriod is how long a circuit stays open before a single
+	// half-open probe is allowed through.
+	CooldownPeriod time.Duration
+	// ringSize bounds memory use; it's a capacity, not a time window.
+	ringSize int
+}
+
+// DefaultHealthTrackerConfig matches the guidance this tracker was built
+// for: open a circuit once more than half of the last minute's requests
+// failed, but only once there's enough signal (20 samples) to trust that.
+func DefaultHealthTrackerConfig() HealthTrackerConfig {
+	return HealthTrackerConfig{
+		WindowSize:         time.Minute,
+		MinSamples:         20,
+		ErrorRateThreshold: 0.5,
+		CooldownPeriod:     30 * time.Second,
+		ringSize:           256,
+	}
+}
+
+// These are registered once at package init, like pkg/gitparse/metrics.go and
+// pkg/sources/docker/metrics.go do, so that building more than one
+// VerificationHealthTracker in the same process (one per detector, as the
+// constructor's doc comment invites) doesn't hit promauto.NewCounterVec's
+// MustRegister a second time and panic on an already-registered descriptor.
+var (
+	verificationCircuitOpens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "verification_circuit_opens_total",
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Help:      "Total number of times a detector's verification circuit breaker opened.",
+	}, []string{"detector", "endpoint"})
+
+	verificationCircuitCloses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "verification_circuit_closes_total",
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Help:      "Total number of times a detector's verification circuit breaker closed.",
+	}, []string{"detector", "endpoint"})
+
+	verificationCircuitProbes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "verification_circuit_probes_total",
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Help:      "Total number of half-open probes attempted against a tripped verification circuit.",
+	}, []string{"detector", "endpoint"})
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type outcomeSample struct {
+	at      time.Time
+	outcome Outcome
+}
+
+type endpointHealth struct {
+	mu sync.Mutex
+
+	ring     []outcomeSample
+	next     int
+	filled   bool
+	state    circuitState
+	openedAt time.Time
+}
+
+// VerificationHealthTracker is a per-(detector, endpoint) circuit breaker
+// for live verification calls. It keeps a ring buffer of recent outcomes
+// and, once the error rate within a sliding window crosses a threshold,
+// skips further verification against that endpoint until a half-open probe
+// succeeds. This keeps one flaky self-hosted instance from tanking
+// throughput across a scan of millions of chunks.
+type VerificationHealthTracker struct {
+	cfg HealthTrackerConfig
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointHealth
+}
+
+// NewVerificationHealthTracker creates a tracker using cfg. Detectors that
+// don't need custom tuning can pass DefaultHealthTrackerConfig(). Multiple
+// trackers can coexist in the same process: the opens/closes/probes metrics
+// are shared package-level CounterVecs keyed by the detector/endpoint labels
+// passed to Allow/Record, not one set of metrics per tracker.
+func NewVerificationHealthTracker(cfg HealthTrackerConfig) *VerificationHealthTracker {
+	if cfg.ringSize == 0 {
+		cfg.ringSize = 256
+	}
+	return &VerificationHealthTracker{
+		cfg:       cfg,
+		endpoints: make(map[string]*endpointHealth),
+	}
+}
+
+func (t *VerificationHealthTracker) healthFor(detector, endpoint string) *endpointHealth {
+	key := detector + "|" + endpoint
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.endpoints[key]
+	if !ok {
+		h = &endpointHealth{ring: make([]outcomeSample, t.cfg.ringSize)}
+		t.endpoints[key] = h
+	}
+	return h
+}
+
+// Allow reports whether a live verification call against (detector,
+// endpoint) should proceed. It returns ErrCircuitOpen if the circuit is
+// open and the cooldown hasn't elapsed. Once the cooldown elapses, exactly
+// one caller is let through as a half-open probe; its outcome, reported via
+// Record, decides whether the circuit closes or reopens.
+func (t *VerificationHealthTracker) Allow(detector, endpoint string) error {
+	h := t.healthFor(detector, endpoint)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch h.state {
+	case circuitOpen:
+		if time.Since(h.openedAt) < t.cfg.CooldownPeriod {
+			return ErrCircuitOpen
+		}
+		h.state = circuitHalfOpen
+		verificationCircuitProbes.WithLabelValues(detector, endpoint).Inc()
+		return nil
+	case circuitHalfOpen:
+		// A probe is already in flight; keep rejecting until it resolves.
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// Record reports the outcome of a verification call made after a
+// successful Allow, updating the rolling window and, if appropriate,
+// tripping or resetting the circuit.
+func (t *VerificationHealthTracker) Record(detector, endpoint string, outcome Outcome) {
+	h := t.healthFor(detector, endpoint)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.ring[h.next] = outcomeSample{at: time.Now(), outcome: outcome}
+	h.next = (h.next + 1) % len(h.ring)
+	if h.next == 0 {
+		h.filled = true
+	}
+
+	if h.state == circuitHalfOpen {
+		if outcome == OutcomeOK {
+			h.state = circuitClosed
+			verificationCircuitCloses.WithLabelValues(detector, endpoint).Inc()
+		} else {
+			h.state = circuitOpen
+			h.openedAt = time.Now()
+		}
+		return
+	}
+
+	total, errs := windowErrorRate(h, t.cfg.WindowSize)
+	if total >= t.cfg.MinSamples && float64(errs)/float64(total) > t.cfg.ErrorRateThreshold {
+		if h.state != circuitOpen {
+			verificationCircuitOpens.WithLabelValues(detector, endpoint).Inc()
+		}
+		h.state = circuitOpen
+		h.openedAt = time.Now()
+	}
+}
+
+var (
+	defaultHealthTrackerOnce sync.Once
+	defaultHealthTracker     *VerificationHealthTracker
+)
+
+// DefaultVerificationHealthTracker returns a shared, lazily-created
+// VerificationHealthTracker configured with DefaultHealthTrackerConfig.
+// This is the one-call opt-in detectors are expected to use unless they
+// have a reason to keep their own tracker (and metrics) separate.
+func DefaultVerificationHealthTracker() *VerificationHealthTracker {
+	defaultHealthTrackerOnce.Do(func() {
+		defaultHealthTracker = NewVerificationHealthTracker(DefaultHealthTrackerConfig())
+	})
+	return defaultHealthTracker
+}
+
+// windowErrorRate returns the total number of samples and the number of
+// non-OK samples recorded within the last window.
+func windowErrorRate(h *endpointHealth, window time.Duration) (total, errs int) {
+	cutoff := time.Now().Add(-window)
+	n := len(h.ring)
+	if !h.filled {
+		n = h.next
+	}
+	for i := 0; i < n; i++ {
+		s := h.ring[i]
+		if s.at.IsZero() || s.at.Before(cutoff) {
+			continue
+		}
+		total++
+		if s.outcome != OutcomeOK {
+			errs++
+		}
+	}
+	return total, errs
+}
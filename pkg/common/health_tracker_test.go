@@ -0,0 +1,177 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func testConfig() HealthTrackerConfig {
+	return HealthTrackerConfig{
+		WindowSize:         time.Minute,
+		MinSamples:         4,
+		ErrorRateThreshold: 0.5,
+		CooldownPeriod:     10 * time.Millisecond,
+		ringSize:           16,
+	}
+}
+
+func TestVerificationHealthTracker_AllowsWhileClosed(t *testing.T) {
+	tr := NewVerificationHealthTracker(testConfig())
+
+	for i := 0; i < 10; i++ {
+		if err := tr.Allow("det", "endpoint"); err != nil {
+			t.Fatalf("Allow() = %v, want nil while circuit is closed", err)
+		}
+		tr.Record("det", "endpoint", OutcomeOK)
+	}
+}
+
+func TestVerificationHealthTracker_OpensAboveErrorRateThreshold(t *testing.T) {
+	tr := NewVerificationHealthTracker(testConfig())
+
+	// 2 OK, 2 errors: right at MinSamples, error rate 0.5 which is not
+	// strictly greater than the threshold, so the circuit should still be
+	// closed.
+	tr.Record("det", "endpoint", OutcomeOK)
+	tr.Record("det", "endpoint", OutcomeOK)
+	tr.Record("det", "endpoint", Outcome5xx)
+	tr.Record("det", "endpoint", Outcome5xx)
+	if err := tr.Allow("det", "endpoint"); err != nil {
+		t.Fatalf("Allow() = %v, want nil at exactly the error rate threshold", err)
+	}
+
+	// One more error tips the rate above 0.5 and should trip the circuit.
+	tr.Record("det", "endpoint", OutcomeTimeout)
+	if err := tr.Allow("det", "endpoint"); err != ErrCircuitOpen {
+		t.Fatalf("Allow() = %v, want ErrCircuitOpen once error rate exceeds threshold", err)
+	}
+}
+
+func TestVerificationHealthTracker_StaysOpenUntilCooldownElapses(t *testing.T) {
+	cfg := testConfig()
+	cfg.CooldownPeriod = 50 * time.Millisecond
+	tr := NewVerificationHealthTracker(cfg)
+
+	for i := 0; i < cfg.MinSamples; i++ {
+		tr.Record("det", "endpoint", Outcome5xx)
+	}
+	if err := tr.Allow("det", "endpoint"); err != ErrCircuitOpen {
+		t.Fatalf("Allow() = %v, want ErrCircuitOpen immediately after tripping", err)
+	}
+
+	// Still within the cooldown window.
+	if err := tr.Allow("det", "endpoint"); err != ErrCircuitOpen {
+		t.Fatalf("Allow() = %v, want ErrCircuitOpen before cooldown elapses", err)
+	}
+
+	time.Sleep(cfg.CooldownPeriod + 10*time.Millisecond)
+
+	if err := tr.Allow("det", "endpoint"); err != nil {
+		t.Fatalf("Allow() = %v, want nil for the half-open probe once cooldown elapses", err)
+	}
+}
+
+func TestVerificationHealthTracker_HalfOpenSerializesASingleProbe(t *testing.T) {
+	cfg := testConfig()
+	cfg.CooldownPeriod = 10 * time.Millisecond
+	tr := NewVerificationHealthTracker(cfg)
+
+	for i := 0; i < cfg.MinSamples; i++ {
+		tr.Record("det", "endpoint", Outcome5xx)
+	}
+	time.Sleep(cfg.CooldownPeriod + 10*time.Millisecond)
+
+	if err := tr.Allow("det", "endpoint"); err != nil {
+		t.Fatalf("Allow() = %v, want nil for the first half-open probe", err)
+	}
+	// A second caller must not also be let through while the first probe's
+	// outcome is still outstanding.
+	if err := tr.Allow("det", "endpoint"); err != ErrCircuitOpen {
+		t.Fatalf("Allow() = %v, want ErrCircuitOpen while a half-open probe is in flight", err)
+	}
+}
+
+func TestVerificationHealthTracker_HalfOpenProbeSuccessCloses(t *testing.T) {
+	cfg := testConfig()
+	cfg.CooldownPeriod = 10 * time.Millisecond
+	tr := NewVerificationHealthTracker(cfg)
+
+	for i := 0; i < cfg.MinSamples; i++ {
+		tr.Record("det", "endpoint", Outcome5xx)
+	}
+	time.Sleep(cfg.CooldownPeriod + 10*time.Millisecond)
+
+	if err := tr.Allow("det", "endpoint"); err != nil {
+		t.Fatalf("Allow() = %v, want nil for the half-open probe", err)
+	}
+	tr.Record("det", "endpoint", OutcomeOK)
+
+	if err := tr.Allow("det", "endpoint"); err != nil {
+		t.Fatalf("Allow() = %v, want nil once the half-open probe succeeds and closes the circuit", err)
+	}
+}
+
+func TestVerificationHealthTracker_HalfOpenProbeFailureReopens(t *testing.T) {
+	cfg := testConfig()
+	cfg.CooldownPeriod = 10 * time.Millisecond
+	tr := NewVerificationHealthTracker(cfg)
+
+	for i := 0; i < cfg.MinSamples; i++ {
+		tr.Record("det", "endpoint", Outcome5xx)
+	}
+	time.Sleep(cfg.CooldownPeriod + 10*time.Millisecond)
+
+	if err := tr.Allow("det", "endpoint"); err != nil {
+		t.Fatalf("Allow() = %v, want nil for the half-open probe", err)
+	}
+	tr.Record("det", "endpoint", Outcome5xx)
+
+	if err := tr.Allow("det", "endpoint"); err != ErrCircuitOpen {
+		t.Fatalf("Allow() = %v, want ErrCircuitOpen once the half-open probe fails and reopens the circuit", err)
+	}
+}
+
+func TestVerificationHealthTracker_EndpointsAreIndependent(t *testing.T) {
+	tr := NewVerificationHealthTracker(testConfig())
+
+	for i := 0; i < tr.cfg.MinSamples; i++ {
+		tr.Record("det", "endpoint-a", Outcome5xx)
+	}
+	if err := tr.Allow("det", "endpoint-a"); err != ErrCircuitOpen {
+		t.Fatalf("Allow(endpoint-a) = %v, want ErrCircuitOpen", err)
+	}
+	if err := tr.Allow("det", "endpoint-b"); err != nil {
+		t.Fatalf("Allow(endpoint-b) = %v, want nil: a tripped circuit on endpoint-a must not affect endpoint-b", err)
+	}
+}
+
+func TestVerificationHealthTracker_SamplesOutsideWindowAreIgnored(t *testing.T) {
+	cfg := testConfig()
+	cfg.WindowSize = 20 * time.Millisecond
+	tr := NewVerificationHealthTracker(cfg)
+
+	// One short of MinSamples, so these alone can't trip the circuit.
+	for i := 0; i < cfg.MinSamples-1; i++ {
+		tr.Record("det", "endpoint", Outcome5xx)
+	}
+	// Let every sample above age out of the window before recording any
+	// more, so the error rate computed on the next Record call should only
+	// see what follows.
+	time.Sleep(cfg.WindowSize + 10*time.Millisecond)
+
+	// Counted together with the stale samples, this error would make
+	// MinSamples failures in a row and trip the circuit; counted alone it is
+	// below MinSamples.
+	tr.Record("det", "endpoint", Outcome5xx)
+	if err := tr.Allow("det", "endpoint"); err != nil {
+		t.Fatalf("Allow() = %v, want nil: stale error samples outside WindowSize must not count toward tripping the circuit", err)
+	}
+}
+
+func TestDefaultVerificationHealthTracker_ReturnsSingleton(t *testing.T) {
+	a := DefaultVerificationHealthTracker()
+	b := DefaultVerificationHealthTracker()
+	if a != b {
+		t.Fatal("DefaultVerificationHealthTracker() returned different instances across calls")
+	}
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type loggerCtxKey struct{}
+
+// WithLogger attaches logger to ctx so it can be retrieved downstream with
+// LoggerFromContext. This is the module-wide logging abstraction detectors
+// and sources thread through context.Context instead of each owning their
+// own logger field.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, logger)
+}
+
+// defaultDedupeWindow bounds how often the fallback logger repeats an
+// identical record, so something like repeated 401s from scanning the same
+// repo doesn't flood output just because the caller never attached its own
+// logger via WithLogger.
+const defaultDedupeWindow = time.Minute
+
+var (
+	defaultLoggerOnce sync.Once
+	defaultLogger     *slog.Logger
+)
+
+// fallbackLogger returns the logger LoggerFromContext uses when ctx has none
+// attached: slog.Default() wrapped in a DedupingHandler, built once and
+// reused so the dedupe window is tracked across calls instead of resetting
+// every time.
+func fallbackLogger() *slog.Logger {
+	defaultLoggerOnce.Do(func() {
+		defaultLogger = slog.New(NewDedupingHandler(slog.Default().Handler(), defaultDedupeWindow))
+	})
+	return defaultLogger
+}
+
+// LoggerFromContext returns the *slog.Logger attached to ctx via
+// WithLogger, or a deduping wrapper around slog.Default() if none was
+// attached.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerCtxKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return fallbackLogger()
+}
@@ -2,9 +2,15 @@ package gitlabv2
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
@@ -13,9 +19,36 @@ import (
 
 type Scanner struct {
 	client *http.Client
+	logger *slog.Logger
 	detectors.EndpointSetter
 }
 
+// New builds a Scanner, applying any options. With no options, it behaves
+// exactly as the zero-value Scanner{} used to.
+func New(opts ...func(*Scanner)) *Scanner {
+	s := &Scanner{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithLogger overrides the logger the Scanner uses for verification
+// diagnostics. Without it, the Scanner falls back to the logger attached to
+// the FromData context via common.WithLogger, or a deduped slog.Default()
+// (see common.LoggerFromContext) so repeated verification errors against the
+// same endpoint don't flood output.
+func WithLogger(logger *slog.Logger) func(*Scanner) {
+	return func(s *Scanner) { s.logger = logger }
+}
+
+func (s Scanner) loggerOrDefault(ctx context.Context) *slog.Logger {
+	if s.logger != nil {
+		return s.logger
+	}
+	return common.LoggerFromContext(ctx)
+}
+
 // Ensure the Scanner satisfies the interfaces at compile time.
 var _ detectors.Detector = (*Scanner)(nil)
 var _ detectors.Versioner = (*Scanner)(nil)
@@ -29,6 +62,22 @@ var (
 	keyPat        = regexp.MustCompile(`\b(glpat-[a-zA-Z0-9\-=_]{20,22})\b`)
 )
 
+// detectorName identifies this detector to common.VerificationHealthTracker.
+const detectorName = "gitlab_v2"
+
+// patSelfResponse is the body of GET /api/v4/personal_access_tokens/self,
+// available on GitLab 16+. It carries the scopes and lifecycle state that
+// the older /api/v4/user probe can't tell us.
+type patSelfResponse struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	UserID     int        `json:"user_id"`
+	Active     bool       `json:"active"`
+	Revoked    bool       `json:"revoked"`
+	ExpiresAt  *string    `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
 // Keywords are used for efficiently pre-filtering chunks.
 // Use identifiers in the secret preferably, or the provider name.
 func (s Scanner) Keywords() []string {
@@ -52,44 +101,20 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 		}
 
 		if verify {
-			// there are 4 read 'scopes' for a gitlab token: api, read_user, read_repo, and read_registry
-			// they all grant access to different parts of the API. I couldn't find an endpoint that every
-			// one of these scopes has access to, so we just check an example endpoint for each scope. If any
-			// of them contain data, we know we have a valid key, but if they all fail, we don't
 			client := s.client
 			if client == nil {
 				client = defaultClient
 			}
+			tracker := common.DefaultVerificationHealthTracker()
+			logger := s.loggerOrDefault(ctx)
 			for _, baseURL := range s.Endpoints(s.DefaultEndpoint()) {
-				// test `read_user` scope
-				req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/v4/user", nil)
-				if err != nil {
-					continue
-				}
-				req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", match[1]))
-				res, err := client.Do(req)
-				if err == nil {
-					res.Body.Close() // The request body is unused.
-
-					// 200 means good key and has `read_user` scope
-					// 403 means good key but not the right scope
-					// 401 is bad key
-					switch res.StatusCode {
-					case http.StatusOK:
-						secret.Verified = true
-					case http.StatusForbidden:
-						// Good key but not the right scope
-						secret.Verified = true
-					case http.StatusUnauthorized:
-						// Nothing to do; zero values are the ones we want
-					default:
-						secret.VerificationError = fmt.Errorf("unexpected HTTP response status %d", res.StatusCode)
-					}
-				} else {
+				if err := tracker.Allow(detectorName, baseURL); err != nil {
 					secret.VerificationError = err
+					continue
 				}
+				outcome := verifyGitlabPAT(ctx, logger, client, baseURL, match[1], &secret)
+				tracker.Record(detectorName, baseURL, outcome)
 			}
-
 		}
 
 		if !secret.Verified && detectors.IsKnownFalsePositive(string(secret.Raw), detectors.DefaultFalsePositives, true) {
@@ -102,6 +127,145 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 	return
 }
 
+// verifyGitlabPAT checks rawSecret against baseURL, preferring
+// GET /api/v4/personal_access_tokens/self (GitLab 16+), which reports the
+// token's scopes, owning user, expiration, and revoked/active state. Older
+// self-managed instances that 404 on that endpoint fall back to the
+// /api/v4/user probe this detector used previously.
+func verifyGitlabPAT(ctx context.Context, logger *slog.Logger, client *http.Client, baseURL, rawSecret string, secret *detectors.Result) common.Outcome {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/v4/personal_access_tokens/self", nil)
+	if err != nil {
+		return common.OutcomeOK
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", rawSecret))
+
+	res, err := client.Do(req)
+	if err != nil {
+		secret.VerificationError = err
+		logger.LogAttrs(ctx, slog.LevelWarn, "gitlab verification request failed",
+			slog.String("endpoint", baseURL),
+			slog.String("detector", detectorName),
+			slog.String("error", err.Error()),
+		)
+		return outcomeForError(err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		var self patSelfResponse
+		if err := json.NewDecoder(res.Body).Decode(&self); err != nil {
+			secret.VerificationError = err
+			return common.OutcomeOK
+		}
+
+		secret.ExtraData = map[string]string{
+			"name":    self.Name,
+			"scopes":  strings.Join(self.Scopes, ","),
+			"user_id": strconv.Itoa(self.UserID),
+		}
+		if self.ExpiresAt != nil {
+			secret.ExtraData["expires_at"] = *self.ExpiresAt
+		}
+		if self.LastUsedAt != nil {
+			secret.ExtraData["last_used_at"] = self.LastUsedAt.String()
+		}
+
+		switch {
+		case self.Revoked:
+			secret.Verified = false
+			secret.VerificationError = fmt.Errorf("token is revoked")
+		case !self.Active:
+			secret.Verified = false
+			secret.VerificationError = fmt.Errorf("token is not active")
+		default:
+			secret.Verified = true
+		}
+		return common.OutcomeOK
+	case http.StatusNotFound:
+		// Older self-managed instances don't expose personal_access_tokens/self.
+		return verifyGitlabUser(ctx, logger, client, baseURL, rawSecret, secret)
+	case http.StatusUnauthorized:
+		// Nothing to do; zero values are the ones we want.
+		return common.OutcomeOK
+	default:
+		secret.VerificationError = fmt.Errorf("unexpected HTTP response status %d", res.StatusCode)
+		logger.LogAttrs(ctx, slog.LevelWarn, "gitlab verification failed",
+			slog.String("endpoint", baseURL),
+			slog.Int("status", res.StatusCode),
+			slog.String("detector", detectorName),
+		)
+		return outcomeForStatus(res.StatusCode)
+	}
+}
+
+// verifyGitlabUser is the pre-GitLab-16 verification probe: there are 4
+// read 'scopes' for a gitlab token: api, read_user, read_repo, and
+// read_registry. They all grant access to different parts of the API, and
+// there's no endpoint every one of these scopes has access to on older
+// instances, so we just check the `read_user` scoped endpoint. If it
+// returns data, or even a 403 (good key, wrong scope), we know the key is
+// valid.
+func verifyGitlabUser(ctx context.Context, logger *slog.Logger, client *http.Client, baseURL, rawSecret string, secret *detectors.Result) common.Outcome {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/v4/user", nil)
+	if err != nil {
+		return common.OutcomeOK
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", rawSecret))
+
+	res, err := client.Do(req)
+	if err != nil {
+		secret.VerificationError = err
+		logger.LogAttrs(ctx, slog.LevelWarn, "gitlab verification request failed",
+			slog.String("endpoint", baseURL),
+			slog.String("detector", detectorName),
+			slog.String("error", err.Error()),
+		)
+		return outcomeForError(err)
+	}
+	res.Body.Close() // The request body is unused.
+
+	// 200 means good key and has `read_user` scope
+	// 403 means good key but not the right scope
+	// 401 is bad key
+	switch res.StatusCode {
+	case http.StatusOK:
+		secret.Verified = true
+	case http.StatusForbidden:
+		// Good key but not the right scope
+		secret.Verified = true
+	case http.StatusUnauthorized:
+		// Nothing to do; zero values are the ones we want
+	default:
+		secret.VerificationError = fmt.Errorf("unexpected HTTP response status %d", res.StatusCode)
+		logger.LogAttrs(ctx, slog.LevelWarn, "gitlab verification failed",
+			slog.String("endpoint", baseURL),
+			slog.Int("status", res.StatusCode),
+			slog.String("detector", detectorName),
+		)
+		return outcomeForStatus(res.StatusCode)
+	}
+	return common.OutcomeOK
+}
+
+// outcomeForError classifies a transport-level error for the health
+// tracker: timeouts are tracked separately from other failures since a slow
+// endpoint degrades throughput differently than one returning hard errors.
+func outcomeForError(err error) common.Outcome {
+	var netErr interface{ Timeout() bool }
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return common.OutcomeTimeout
+	}
+	return common.Outcome5xx
+}
+
+func outcomeForStatus(statusCode int) common.Outcome {
+	if statusCode >= 500 {
+		return common.Outcome5xx
+	}
+	return common.OutcomeOK
+}
+
 func (s Scanner) Type() detectorspb.DetectorType {
 	return detectorspb.DetectorType_Gitlab
 }
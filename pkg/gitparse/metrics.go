@@ -1,6 +1,8 @@
 package gitparse
 
 import (
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
@@ -23,11 +25,54 @@ var (
 		Help:      "Duration of consuming a diff.",
 		Buckets:   prometheus.ExponentialBuckets(1, 10, 8),
 	})
+
+	diffsProducedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "diffs_produced_total",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Total number of diffs produced onto a DiffChan.",
+	})
+
+	diffsConsumedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "diffs_consumed_total",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Total number of diffs consumed from a DiffChan.",
+	})
+
+	diffsDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "diffs_dropped_total",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Total number of diffs dropped without being consumed.",
+	})
+
+	diffSizeBytes = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:      "diff_size_bytes",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Size in bytes of each diff produced.",
+		Buckets:   prometheus.ExponentialBuckets(1<<10, 4, 10), // 1KB .. ~256MB
+	})
+
+	commitFileCount = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:      "commit_file_count",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Number of files touched per commit.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	})
 )
 
 type metrics struct {
 	produceDiffDuration prometheus.Histogram
 	consumeDiffDuration prometheus.Histogram
+
+	diffsProduced   prometheus.Counter
+	diffsConsumed   prometheus.Counter
+	diffsDropped    prometheus.Counter
+	diffSizeBytes   prometheus.Histogram
+	commitFileCount prometheus.Histogram
 }
 
 // newDiffChanMetrics creates a new metrics instance configured with Prometheus metrics specific to a DiffChan.
@@ -47,10 +92,24 @@ type metrics struct {
 //
 // The metrics are created with a common namespace and subsystem defined in the metrics package.
 // This helps to organize and group related metrics together.
-func newDiffChanMetrics() *metrics {
+//
+// chanDepth, if non-nil, is polled lazily on each Prometheus scrape (via a
+// registered prometheus.Collector) to report the DiffChan's current
+// in-flight depth, rather than requiring a Set() call at every send/receive
+// site.
+func newDiffChanMetrics(chanDepth func() int) *metrics {
+	if chanDepth != nil {
+		registerDiffChanDepthCollector(chanDepth)
+	}
+
 	return &metrics{
 		produceDiffDuration: produceDiffDuration,
 		consumeDiffDuration: consumeDiffDuration,
+		diffsProduced:       diffsProducedTotal,
+		diffsConsumed:       diffsConsumedTotal,
+		diffsDropped:        diffsDroppedTotal,
+		diffSizeBytes:       diffSizeBytes,
+		commitFileCount:     commitFileCount,
 	}
 }
 
@@ -60,4 +119,85 @@ func (m *metrics) observeProduceDiffDuration(duration float64) {
 
 func (m *metrics) observeConsumeDiffDuration(duration float64) {
 	m.consumeDiffDuration.Observe(duration)
-}
\ No newline at end of file
+}
+
+func (m *metrics) observeDiffProduced(sizeBytes float64) {
+	m.diffsProduced.Inc()
+	m.diffSizeBytes.Observe(sizeBytes)
+}
+
+func (m *metrics) observeDiffConsumed() {
+	m.diffsConsumed.Inc()
+}
+
+func (m *metrics) observeDiffDropped() {
+	m.diffsDropped.Inc()
+}
+
+func (m *metrics) observeCommitFileCount(fileCount float64) {
+	m.commitFileCount.Observe(fileCount)
+}
+
+// diffChanDepthCollector is a prometheus.Collector over a DiffChan's
+// current length. It's implemented as a collector instead of a
+// promauto.Gauge so the depth can be read lazily at scrape time from
+// len(chan), rather than requiring a Set() call at every produce/consume
+// site.
+//
+// Its target depth func is mutable behind a mutex so that a second DiffChan
+// created in the same process can repoint the already-registered collector
+// at its own depth func instead of trying to register a second collector
+// under the same metric name.
+type diffChanDepthCollector struct {
+	desc *prometheus.Desc
+
+	mu    sync.RWMutex
+	depth func() int
+}
+
+func newDiffChanDepthCollector(depth func() int) *diffChanDepthCollector {
+	return &diffChanDepthCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(common.MetricsNamespace, common.MetricsSubsystem, "diff_chan_depth"),
+			"Current number of diffs buffered in the DiffChan.",
+			nil, nil,
+		),
+		depth: depth,
+	}
+}
+
+func (c *diffChanDepthCollector) setDepth(depth func() int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.depth = depth
+}
+
+func (c *diffChanDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *diffChanDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	depth := c.depth
+	c.mu.RUnlock()
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(depth()))
+}
+
+var (
+	sharedDiffChanDepthCollectorOnce sync.Once
+	sharedDiffChanDepthCollector     *diffChanDepthCollector
+)
+
+// registerDiffChanDepthCollector registers this process's DiffChan-depth
+// collector on first call, and on every later call repoints it at depth.
+// This is what lets a second DiffChan opened in the same process share the
+// already-registered collector instead of triggering a
+// prometheus.AlreadyRegisteredError that would otherwise leave its depth
+// unreported.
+func registerDiffChanDepthCollector(depth func() int) {
+	sharedDiffChanDepthCollectorOnce.Do(func() {
+		sharedDiffChanDepthCollector = newDiffChanDepthCollector(depth)
+		prometheus.MustRegister(sharedDiffChanDepthCollector)
+	})
+	sharedDiffChanDepthCollector.setDepth(depth)
+}
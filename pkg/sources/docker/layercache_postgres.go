@@ -0,0 +1,106 @@
+package docker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+// postgresLayerCache is a LayerCache backed by PostgreSQL. It exists so
+// multiple trufflehog workers scanning the same registry can share layer
+// results through a single server instead of each keeping a private SQLite
+// file.
+type postgresLayerCache struct {
+	dsn string
+	db  *sql.DB
+}
+
+func newPostgresLayerCache(dsn string) *postgresLayerCache {
+	return &postgresLayerCache{dsn: dsn}
+}
+
+func (c *postgresLayerCache) Initialize(ctx context.Context) error {
+	db, err := sql.Open("postgres", c.dsn)
+	if err != nil {
+		common.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelError, "error connecting to layers db", slog.String("error", err.Error()))
+		return fmt.Errorf("error connecting to database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return fmt.Errorf("error pinging database: %w", err)
+	}
+
+	if err := runMigrations(db); err != nil {
+		db.Close()
+		return err
+	}
+
+	c.db = db
+	registerLayerMetrics(db)
+	return nil
+}
+
+func (c *postgresLayerCache) Add(ctx context.Context, digest string) error {
+	defer observeLayerCacheQuery(time.Now())
+	_, err := c.db.Exec(`INSERT INTO digest (digest, verified, unverified_with_error, completed, first_seen, last_seen)
+		VALUES ($1, false, false, false, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (digest) DO UPDATE SET last_seen = CURRENT_TIMESTAMP`, digest)
+	return err
+}
+
+func (c *postgresLayerCache) ShouldSkip(ctx context.Context, digest string) (bool, error) {
+	defer observeLayerCacheQuery(time.Now())
+
+	var verified, unverifiedWithError bool
+	err := c.db.QueryRow("SELECT verified, unverified_with_error FROM digest WHERE digest = $1 AND completed = true", digest).Scan(&verified, &unverifiedWithError)
+	if err == sql.ErrNoRows {
+		layerCacheMisses.Inc()
+		common.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "layer cache miss", slog.String("digest", digest))
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !verified && !unverifiedWithError {
+		layerCacheHits.Inc()
+		layersSkipped.Inc()
+		return true, nil
+	}
+	layerCacheMisses.Inc()
+	common.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "layer cache miss", slog.String("digest", digest))
+	return false, nil
+}
+
+func (c *postgresLayerCache) MarkVerified(ctx context.Context, digest string) error {
+	_, err := c.db.Exec("UPDATE digest SET verified = true, last_seen = CURRENT_TIMESTAMP WHERE digest = $1", digest)
+	return err
+}
+
+func (c *postgresLayerCache) MarkUnverifiedWithError(ctx context.Context, digest string) error {
+	_, err := c.db.Exec("UPDATE digest SET unverified_with_error = true, last_seen = CURRENT_TIMESTAMP WHERE digest = $1", digest)
+	return err
+}
+
+func (c *postgresLayerCache) MarkCompleted(ctx context.Context, digest string) error {
+	_, err := c.db.Exec("UPDATE digest SET completed = true, last_seen = CURRENT_TIMESTAMP WHERE digest = $1", digest)
+	// Every layer that finishes scanning is counted here, regardless of
+	// whether anything was found, so docker_layers_scanned_total is
+	// comparable to docker_layers_skipped_total; MarkVerified/
+	// MarkUnverifiedWithError fire only when a secret turns up.
+	layersScanned.Inc()
+	return err
+}
+
+func (c *postgresLayerCache) Close() error {
+	if c.db == nil {
+		return nil
+	}
+	return c.db.Close()
+}
@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+// redisLayerCache is a LayerCache backed by Redis. Digests are tracked as
+// keys under a common prefix with a small hash of fields, which keeps
+// per-digest reads/writes O(1) and lets many workers share a cache without
+// contending on a single writer the way a SQL backend does.
+type redisLayerCache struct {
+	addr string
+	rdb  *redis.Client
+}
+
+const redisLayerKeyPrefix = "trufflehog:layer:"
+
+func newRedisLayerCache(addr string) *redisLayerCache {
+	return &redisLayerCache{addr: addr}
+}
+
+func (c *redisLayerCache) Initialize(ctx context.Context) error {
+	opts, err := redis.ParseURL(c.addr)
+	if err != nil {
+		return err
+	}
+	c.rdb = redis.NewClient(opts)
+	if err := c.rdb.Ping(ctx).Err(); err != nil {
+		common.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelError, "error connecting to layers db", slog.String("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Unlike the SQL backends, redisLayerCache doesn't call registerLayerMetrics:
+// docker_layer_cache_entries and the connection-pool stats collector are both
+// defined in terms of a *sql.DB, which Redis has no equivalent of. The
+// layerCacheHits/Misses/Skipped/Scanned counters are still shared across all
+// three backends below.
+
+func (c *redisLayerCache) key(digest string) string {
+	return redisLayerKeyPrefix + digest
+}
+
+func (c *redisLayerCache) Add(ctx context.Context, digest string) error {
+	key := c.key(digest)
+
+	// HSetNX leaves any fields already present untouched, so re-adding a
+	// digest that's recurred in another image (or a second scan run) can't
+	// clobber its existing verified/unverified_with_error/completed state
+	// back to false the way an unconditional HSet/REPLACE would.
+	pipe := c.rdb.Pipeline()
+	pipe.HSetNX(ctx, key, fieldVerified, false)
+	pipe.HSetNX(ctx, key, fieldUnverifiedWithError, false)
+	pipe.HSetNX(ctx, key, fieldCompleted, false)
+	pipe.HSetNX(ctx, key, fieldFirstSeen, time.Now().Unix())
+	pipe.HSet(ctx, key, fieldLastSeen, time.Now().Unix())
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *redisLayerCache) ShouldSkip(ctx context.Context, digest string) (bool, error) {
+	res, err := c.rdb.HGetAll(ctx, c.key(digest)).Result()
+	if err != nil {
+		return false, err
+	}
+	if len(res) == 0 || res[fieldCompleted] != "1" {
+		layerCacheMisses.Inc()
+		common.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "layer cache miss", slog.String("digest", digest))
+		return false, nil
+	}
+	if res[fieldVerified] != "1" && res[fieldUnverifiedWithError] != "1" {
+		layerCacheHits.Inc()
+		layersSkipped.Inc()
+		return true, nil
+	}
+	layerCacheMisses.Inc()
+	common.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "layer cache miss", slog.String("digest", digest))
+	return false, nil
+}
+
+func (c *redisLayerCache) MarkVerified(ctx context.Context, digest string) error {
+	return c.rdb.HSet(ctx, c.key(digest), fieldVerified, true, fieldLastSeen, time.Now().Unix()).Err()
+}
+
+func (c *redisLayerCache) MarkUnverifiedWithError(ctx context.Context, digest string) error {
+	return c.rdb.HSet(ctx, c.key(digest), fieldUnverifiedWithError, true, fieldLastSeen, time.Now().Unix()).Err()
+}
+
+func (c *redisLayerCache) MarkCompleted(ctx context.Context, digest string) error {
+	err := c.rdb.HSet(ctx, c.key(digest), fieldCompleted, true, fieldLastSeen, time.Now().Unix()).Err()
+	// Every layer that finishes scanning is counted here, regardless of
+	// whether anything was found, so docker_layers_scanned_total is
+	// comparable to docker_layers_skipped_total; MarkVerified/
+	// MarkUnverifiedWithError fire only when a secret turns up.
+	layersScanned.Inc()
+	return err
+}
+
+func (c *redisLayerCache) Close() error {
+	if c.rdb == nil {
+		return nil
+	}
+	return c.rdb.Close()
+}
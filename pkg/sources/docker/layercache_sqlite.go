@@ -0,0 +1,164 @@
+package docker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+// sqliteLayerCache is the default LayerCache backend. Unlike the old
+// connect-per-call helpers it keeps a single pooled *sql.DB (and a cache of
+// prepared statements) for the lifetime of the scan, so concurrent workers
+// share one connection pool instead of opening a fresh SQLite handle per
+// digest.
+type sqliteLayerCache struct {
+	dbName string
+
+	mu    sync.Mutex
+	db    *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+func newSQLiteLayerCache(dbName string) *sqliteLayerCache {
+	return &sqliteLayerCache{dbName: dbName, stmts: make(map[string]*sql.Stmt)}
+}
+
+func (c *sqliteLayerCache) Initialize(ctx context.Context) error {
+	db, err := sql.Open("sqlite3", c.dbName)
+	if err != nil {
+		common.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelError, "error connecting to layers db", slog.String("error", err.Error()))
+		return fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	// A single *sql.DB is shared for the life of the scan, so enable WAL so
+	// concurrent readers don't block the writer(s).
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return fmt.Errorf("error enabling WAL mode: %w", err)
+	}
+
+	if err := runMigrations(db); err != nil {
+		db.Close()
+		return err
+	}
+
+	c.db = db
+	registerLayerMetrics(db)
+	return nil
+}
+
+func (c *sqliteLayerCache) prepared(query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (c *sqliteLayerCache) Add(ctx context.Context, digest string) error {
+	defer observeLayerCacheQuery(time.Now())
+
+	// ON CONFLICT DO UPDATE only touches last_seen: digest is a real PRIMARY
+	// KEY (see migrations.go), so re-adding one that's recurred in another
+	// image, or a second scan run, must not reset its
+	// verified/unverified_with_error/completed state back to false the way
+	// INSERT OR REPLACE used to, and must not overwrite first_seen either.
+	stmt, err := c.prepared(`INSERT INTO digest
+		(digest, verified, unverified_with_error, completed, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT(digest) DO UPDATE SET last_seen = CURRENT_TIMESTAMP`)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(digest, false, false, false)
+	return err
+}
+
+func (c *sqliteLayerCache) ShouldSkip(ctx context.Context, digest string) (bool, error) {
+	defer observeLayerCacheQuery(time.Now())
+
+	stmt, err := c.prepared("SELECT verified, unverified_with_error FROM digest WHERE digest = ? and completed = true")
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := stmt.Query(digest)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var verified, unverifiedWithError bool
+		if err := rows.Scan(&verified, &unverifiedWithError); err != nil {
+			return false, err
+		}
+		if !verified && !unverifiedWithError {
+			layerCacheHits.Inc()
+			layersSkipped.Inc()
+			return true, nil
+		}
+	}
+	layerCacheMisses.Inc()
+	common.LoggerFromContext(ctx).LogAttrs(ctx, slog.LevelDebug, "layer cache miss", slog.String("digest", digest))
+	return false, nil
+}
+
+func (c *sqliteLayerCache) MarkVerified(ctx context.Context, digest string) error {
+	stmt, err := c.prepared("UPDATE digest SET verified = true, last_seen = CURRENT_TIMESTAMP WHERE digest = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(digest)
+	return err
+}
+
+func (c *sqliteLayerCache) MarkUnverifiedWithError(ctx context.Context, digest string) error {
+	stmt, err := c.prepared("UPDATE digest SET unverified_with_error = true, last_seen = CURRENT_TIMESTAMP WHERE digest = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(digest)
+	return err
+}
+
+func (c *sqliteLayerCache) MarkCompleted(ctx context.Context, digest string) error {
+	stmt, err := c.prepared("UPDATE digest SET completed = ?, last_seen = CURRENT_TIMESTAMP WHERE digest = ?")
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(true, digest)
+	// Every layer that finishes scanning is counted here, regardless of
+	// whether anything was found, so docker_layers_scanned_total is
+	// comparable to docker_layers_skipped_total; MarkVerified/
+	// MarkUnverifiedWithError fire only when a secret turns up.
+	layersScanned.Inc()
+	return err
+}
+
+func (c *sqliteLayerCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
+	if c.db == nil {
+		return nil
+	}
+	return c.db.Close()
+}
@@ -0,0 +1,210 @@
+package docker
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	layerCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "docker_layer_cache_hits_total",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Total number of Docker layers found already cached.",
+	})
+
+	layerCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "docker_layer_cache_misses_total",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Total number of Docker layers not found in the cache.",
+	})
+
+	layersSkipped = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "docker_layers_skipped_total",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Total number of Docker layers skipped because they were already scanned clean.",
+	})
+
+	layersScanned = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "docker_layers_scanned_total",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Total number of Docker layers scanned.",
+	})
+
+	layerCacheQueryDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:      "docker_layer_cache_query_duration_microseconds",
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Help:      "Duration of a layer-cache connect/query round trip.",
+		Buckets:   prometheus.ExponentialBuckets(1, 10, 8),
+	})
+)
+
+// observeLayerCacheQuery records how long a layer-cache connect/query round
+// trip took, given its start time. Shared by every SQL backend so the
+// docker_layer_cache_query_duration_microseconds histogram covers sqlite and
+// postgres the same way.
+func observeLayerCacheQuery(start time.Time) {
+	layerCacheQueryDuration.Observe(float64(time.Since(start).Microseconds()))
+}
+
+// layerCacheEntriesCollector is a prometheus.Collector that reports the
+// current number of rows in the layer cache. It's implemented as a
+// collector rather than a promauto.Gauge because the count has to be read
+// lazily on each scrape instead of being kept up to date on every write.
+//
+// Its target db is mutable behind a mutex so that a second LayerCache
+// opened in the same process (by a second scan, for example) can
+// repoint the already-registered collector at its own *sql.DB instead of
+// trying to register a second collector under the same metric name.
+type layerCacheEntriesCollector struct {
+	desc *prometheus.Desc
+
+	mu sync.RWMutex
+	db *sql.DB
+}
+
+// newLayerCacheEntriesCollector returns a collector that runs
+// `SELECT COUNT(*) FROM digest` against db on each scrape.
+func newLayerCacheEntriesCollector(db *sql.DB) *layerCacheEntriesCollector {
+	return &layerCacheEntriesCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(common.MetricsNamespace, common.MetricsSubsystem, "docker_layer_cache_entries"),
+			"Current number of digests tracked in the Docker layer cache.",
+			nil, nil,
+		),
+		db: db,
+	}
+}
+
+func (c *layerCacheEntriesCollector) setDB(db *sql.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.db = db
+}
+
+func (c *layerCacheEntriesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *layerCacheEntriesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	db := c.db
+	c.mu.RUnlock()
+
+	var count float64
+	if err := db.QueryRow("SELECT COUNT(*) FROM digest").Scan(&count); err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, count)
+}
+
+// dbStatsCollector is a prometheus.Collector over sql.DB.Stats(), so
+// operators running long scans can see connection pressure (open/in-use/
+// idle connections, wait count/duration) in Grafana. Like
+// layerCacheEntriesCollector, its target db is mutable so a second
+// LayerCache in the same process reuses the already-registered collector.
+type dbStatsCollector struct {
+	mu sync.RWMutex
+	db *sql.DB
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+func newDBStatsCollector(db *sql.DB) *dbStatsCollector {
+	fq := func(name string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(common.MetricsNamespace, common.MetricsSubsystem, "docker_layer_db_"+name),
+			"Docker layer cache connection pool: "+name,
+			nil, nil,
+		)
+	}
+	return &dbStatsCollector{
+		db:                db,
+		maxOpen:           fq("max_open_connections"),
+		open:              fq("open_connections"),
+		inUse:             fq("in_use_connections"),
+		idle:              fq("idle_connections"),
+		waitCount:         fq("wait_count"),
+		waitDuration:      fq("wait_duration_seconds_total"),
+		maxIdleClosed:     fq("max_idle_closed_total"),
+		maxLifetimeClosed: fq("max_lifetime_closed_total"),
+	}
+}
+
+func (c *dbStatsCollector) setDB(db *sql.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.db = db
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxLifetimeClosed
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	db := c.db
+	c.mu.RUnlock()
+
+	stats := db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
+
+var (
+	sharedEntriesCollectorOnce sync.Once
+	sharedEntriesCollector     *layerCacheEntriesCollector
+
+	sharedDBStatsCollectorOnce sync.Once
+	sharedDBStatsCollector     *dbStatsCollector
+)
+
+// registerLayerMetrics registers this process's layer-cache entries and
+// DB-stats collectors on first call, and on every later call repoints them
+// at db. This is what lets a second SQL-backed LayerCache opened in the
+// same process share the already-registered collectors instead of
+// triggering a prometheus.AlreadyRegisteredError that would otherwise leave
+// its metrics unexposed.
+func registerLayerMetrics(db *sql.DB) {
+	sharedEntriesCollectorOnce.Do(func() {
+		sharedEntriesCollector = newLayerCacheEntriesCollector(db)
+		prometheus.MustRegister(sharedEntriesCollector)
+	})
+	sharedEntriesCollector.setDB(db)
+
+	sharedDBStatsCollectorOnce.Do(func() {
+		sharedDBStatsCollector = newDBStatsCollector(db)
+		prometheus.MustRegister(sharedDBStatsCollector)
+	})
+	sharedDBStatsCollector.setDB(db)
+}
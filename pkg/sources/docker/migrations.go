@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migration is one versioned step applied to the layers DB. Migrations run
+// in ascending version order, each inside its own transaction, with the
+// current version stamped into schema_migrations only after its Up
+// succeeds.
+type migration struct {
+	version int
+	up      func(*sql.Tx) error
+}
+
+// migrations is the single, shared source of truth for the layers DB
+// schema: every SQL-backed LayerCache (sqlite, postgres) applies this same
+// ordered list via runMigrations on Initialize, instead of hand-rolling its
+// own CREATE TABLE. The statements below only use syntax both backends
+// support, so there's one schema definition to keep in sync, not one per
+// backend.
+var migrations = []migration{
+	{
+		// The original schema: one row per digest. digest is a real PRIMARY
+		// KEY (not just a bare UNIQUE column) so callers can upsert against
+		// it with ON CONFLICT(digest).
+		version: 1,
+		up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS digest (
+				digest                TEXT PRIMARY KEY,
+				verified              BOOLEAN NOT NULL DEFAULT false,
+				unverified_with_error BOOLEAN NOT NULL DEFAULT false,
+				completed             BOOLEAN NOT NULL DEFAULT false
+			)`)
+			return err
+		},
+	},
+	{
+		// first_seen/last_seen let stale entries be GC'd.
+		version: 2,
+		up: func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`ALTER TABLE digest ADD COLUMN first_seen TIMESTAMP`); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`ALTER TABLE digest ADD COLUMN last_seen TIMESTAMP`); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`UPDATE digest SET first_seen = CURRENT_TIMESTAMP, last_seen = CURRENT_TIMESTAMP WHERE first_seen IS NULL`)
+			return err
+		},
+	},
+	{
+		// digest is already the table's PRIMARY KEY, so a per-digest lookup
+		// like ShouldSkip's WHERE digest = ? AND completed = true is already
+		// a unique-key seek and gets nothing from this index. This instead
+		// supports aggregate queries over completed/verified state that
+		// don't filter by digest at all (e.g. counting how many digests are
+		// still incomplete, or a future GC pass over fully-scanned clean
+		// entries) once the table grows past tens of millions of rows.
+		version: 3,
+		up: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_digest_completed_verified
+				ON digest (completed, verified, unverified_with_error)`)
+			return err
+		},
+	},
+}
+
+// runMigrations applies any migrations in migrations not yet recorded in
+// schema_migrations, in order, each inside its own transaction.
+func runMigrations(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("error creating schema_migrations table: %w", err)
+	}
+
+	applied := make(map[int]bool)
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning schema_migrations: %w", err)
+		}
+		applied[v] = true
+	}
+	rows.Close()
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("error starting migration %d: %w", m.version, err)
+		}
+		if err := m.up(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying migration %d: %w", m.version, err)
+		}
+		// m.version is an int from our own migrations slice, never user
+		// input, and placeholder syntax (`?` vs `$1`) differs across the
+		// sqlite/postgres drivers that share this runner, so it's inlined
+		// directly rather than bound as a parameter.
+		if _, err := tx.Exec(fmt.Sprintf(`INSERT INTO schema_migrations (version) VALUES (%d)`, m.version)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error stamping migration %d: %w", m.version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("error committing migration %d: %w", m.version, err)
+		}
+	}
+
+	return nil
+}
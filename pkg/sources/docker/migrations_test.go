@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("error opening in-memory test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func countRows(t *testing.T, db *sql.DB, query string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatalf("query %q failed: %v", query, err)
+	}
+	return n
+}
+
+func TestRunMigrations_AppliesAllMigrations(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := runMigrations(db); err != nil {
+		t.Fatalf("runMigrations() = %v, want nil", err)
+	}
+
+	got := countRows(t, db, `SELECT COUNT(*) FROM schema_migrations`)
+	if got != len(migrations) {
+		t.Fatalf("schema_migrations has %d rows, want %d", got, len(migrations))
+	}
+
+	// first_seen/last_seen (migration 2) and the composite index
+	// (migration 3) should both be usable once runMigrations returns.
+	if _, err := db.Exec(`INSERT INTO digest (digest, first_seen, last_seen) VALUES ('sha256:abc', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("inserting into migrated schema: %v", err)
+	}
+	got = countRows(t, db, `SELECT COUNT(*) FROM digest WHERE completed = false`)
+	if got != 1 {
+		t.Fatalf("digest row count = %d, want 1", got)
+	}
+}
+
+func TestRunMigrations_IsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := runMigrations(db); err != nil {
+		t.Fatalf("first runMigrations() = %v, want nil", err)
+	}
+	if _, err := db.Exec(`INSERT INTO digest (digest) VALUES ('sha256:abc')`); err != nil {
+		t.Fatalf("inserting a row after first run: %v", err)
+	}
+
+	// Re-running against an already-migrated DB must be a no-op: it must
+	// not re-create tables, re-run ALTER TABLE (which would error on a
+	// duplicate column), or touch existing rows.
+	if err := runMigrations(db); err != nil {
+		t.Fatalf("second runMigrations() = %v, want nil", err)
+	}
+
+	gotMigrations := countRows(t, db, `SELECT COUNT(*) FROM schema_migrations`)
+	if gotMigrations != len(migrations) {
+		t.Fatalf("schema_migrations has %d rows after re-run, want %d", gotMigrations, len(migrations))
+	}
+	gotDigests := countRows(t, db, `SELECT COUNT(*) FROM digest`)
+	if gotDigests != 1 {
+		t.Fatalf("digest has %d rows after re-run, want 1 (row from before re-run must survive)", gotDigests)
+	}
+}
+
+func TestRunMigrations_OnlyAppliesMissingVersions(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		t.Fatalf("creating schema_migrations: %v", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("db.Begin(): %v", err)
+	}
+	if err := migrations[0].up(tx); err != nil {
+		t.Fatalf("applying migration 1 directly: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("committing migration 1: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version) VALUES (1)`); err != nil {
+		t.Fatalf("stamping migration 1 as already applied: %v", err)
+	}
+
+	if err := runMigrations(db); err != nil {
+		t.Fatalf("runMigrations() = %v, want nil", err)
+	}
+
+	got := countRows(t, db, `SELECT COUNT(*) FROM schema_migrations`)
+	if got != len(migrations) {
+		t.Fatalf("schema_migrations has %d rows, want %d (migration 1 pre-applied, 2 and 3 should still run)", got, len(migrations))
+	}
+}